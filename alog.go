@@ -47,9 +47,13 @@ type Logger interface {
 
 // Log 日志对象
 type Log struct {
-	CLogger *consoleLogger // 终端日志输出器
-	FLogger *fileLogger    // 文件日志输出器
-	isClose bool           // 日志对象是否被关闭
+	CLogger *consoleLogger         // 终端日志输出器
+	FLogger *fileLogger            // 文件日志输出器
+	KLogger *kafkaLogger           // Kafka日志输出器
+	isClose bool                   // 日志对象是否被关闭
+	fields  map[string]interface{} // With/WithFields累积的上下文字段
+	hooks   []*hookWorker          // AddHook注册的观察者
+	sampler Sampler                // SetSampler设置的采样/限流器
 }
 
 // New 构造日志对象,
@@ -97,6 +101,22 @@ func (l *Log) SetBothMode() *Log {
 	return l
 }
 
+// SetKafkaMode 设置仅Kafka输出模式
+func (l *Log) SetKafkaMode() *Log {
+	l.CLogger = nil
+	l.FLogger = nil
+	l.KLogger = newKafkaLogger()
+	return l
+}
+
+// SetAllMode 设置控制台、文件、Kafka三路输出模式
+func (l *Log) SetAllMode() *Log {
+	l.CLogger = newConsoleLogger()
+	l.FLogger = newFileLogger()
+	l.KLogger = newKafkaLogger()
+	return l
+}
+
 // SetFilePath 设置输出文件的路径
 func (l *Log) SetFilePath(path string) *Log {
 	if l.FLogger != nil {
@@ -122,6 +142,9 @@ func (l *Log) SetLevel(str string) *Log {
 	if l.FLogger != nil {
 		l.FLogger.level = level
 	}
+	if l.KLogger != nil {
+		l.KLogger.level = level
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -136,13 +159,16 @@ func (l *Log) SetTimeFormat(format string) *Log {
 	if l.FLogger != nil {
 		l.FLogger.timeFormat = format
 	}
+	if l.KLogger != nil {
+		l.KLogger.timeFormat = format
+	}
 	return l
 }
 
 // SetSplitMode 设置文件切割模式
 func (l *Log) SetSplitMode(mode int16) *Log {
 	if l.FLogger != nil {
-		l.FLogger.splitMode = mode
+		l.FLogger.setSplitMode(mode)
 	}
 	return l
 }
@@ -162,59 +188,274 @@ func (l *Log) SetSplitDuration(duration time.Duration) *Log {
 		os.Exit(1)
 	}
 	if l.FLogger != nil {
-		l.FLogger.duration = duration
-		now := time.Now()
-		l.FLogger.startTime = now.Add(-time.Duration(now.UnixNano() % duration.Nanoseconds()))
+		l.FLogger.setSplitDuration(duration)
+	}
+	return l
+}
+
+// SetFormatter 设置日志输出的格式化器，内置TextFormatter(默认)和JSONFormatter
+func (l *Log) SetFormatter(formatter Formatter) *Log {
+	if l.CLogger != nil {
+		l.CLogger.formatter = formatter
+	}
+	if l.FLogger != nil {
+		l.FLogger.formatter = formatter
+	}
+	return l
+}
+
+// WithFields 返回一个携带额外上下文字段的子Log对象，原Log对象不受影响
+func (l *Log) WithFields(fields map[string]interface{}) *Log {
+	merged := make(map[string]interface{}, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	child := *l
+	child.fields = merged
+	return &child
+}
+
+// With 返回一个携带单个上下文字段的子Log对象，是WithFields的简化写法
+func (l *Log) With(key string, value interface{}) *Log {
+	return l.WithFields(map[string]interface{}{key: value})
+}
+
+// SetKafkaTopic 设置Kafka发布日志所使用的topic(仅Kafka模式生效)
+func (l *Log) SetKafkaTopic(topic string) *Log {
+	if l.KLogger != nil {
+		l.KLogger.topic = topic
+	}
+	return l
+}
+
+// SetKafkaBatchSize 设置Kafka每批次发送的最大消息数(仅Kafka模式生效)
+func (l *Log) SetKafkaBatchSize(size int) *Log {
+	if l.KLogger != nil {
+		l.KLogger.batchSize = size
+	}
+	return l
+}
+
+// SetKafkaFlushInterval 设置Kafka凑不满一批时的最长等待时间(仅Kafka模式生效)
+func (l *Log) SetKafkaFlushInterval(interval time.Duration) *Log {
+	if l.KLogger != nil {
+		l.KLogger.flushInterval = interval
+	}
+	return l
+}
+
+// SetKafkaProducer 设置Kafka实际使用的生产者实现，如基于sarama或kgo封装的Producer(仅Kafka模式生效)
+func (l *Log) SetKafkaProducer(producer Producer) *Log {
+	if l.KLogger != nil {
+		l.KLogger.producer = producer
+	}
+	return l
+}
+
+// SetCompressRotated 设置是否将按时间/大小切割出来的备份文件压缩为.gz(仅文件模式生效)
+func (l *Log) SetCompressRotated(compress bool) *Log {
+	if l.FLogger != nil {
+		l.FLogger.compressRotated = compress
+	}
+	return l
+}
+
+// SetMaxBackups 设置最多保留的备份文件数量，超出的旧文件会在下次切割时被清理，0表示不限制(仅文件模式生效)
+func (l *Log) SetMaxBackups(n int) *Log {
+	if l.FLogger != nil {
+		l.FLogger.maxBackups = n
+	}
+	return l
+}
+
+// SetMaxAge 设置备份文件的最长保留时间，超期的旧文件会在下次切割时被清理，0表示不限制(仅文件模式生效)
+func (l *Log) SetMaxAge(d time.Duration) *Log {
+	if l.FLogger != nil {
+		l.FLogger.maxAge = d
 	}
 	return l
 }
 
+// SetOverflowPolicy 设置文件日志环形缓冲区写满后的处理策略(仅文件模式生效)
+func (l *Log) SetOverflowPolicy(policy OverflowPolicy) *Log {
+	if l.FLogger != nil {
+		l.FLogger.ring.setPolicy(policy)
+	}
+	return l
+}
+
+// DroppedCount 返回文件日志因环形缓冲区写满而被丢弃的日志数量
+func (l *Log) DroppedCount() uint64 {
+	if l.FLogger != nil {
+		return l.FLogger.DroppedCount()
+	}
+	return 0
+}
+
+// WrittenCount 返回文件日志成功写入环形缓冲区的日志数量
+func (l *Log) WrittenCount() uint64 {
+	if l.FLogger != nil {
+		return l.FLogger.WrittenCount()
+	}
+	return 0
+}
+
 // Close 关闭日志对象
 func (l *Log) Close() {
 	if l.FLogger != nil {
 		l.FLogger.Close()
 	}
+	if l.KLogger != nil {
+		l.KLogger.Close()
+	}
+	for _, hw := range l.hooks {
+		hw.Close()
+	}
 }
 
 func (l *Log) Debug(format string, a ...interface{}) {
+	if l.sampler != nil && !l.sampler.Allow(DEBUG, format) {
+		return
+	}
 	if l.FLogger != nil && !l.isClose {
-		l.FLogger.Debug(format, a...)
+		if len(l.fields) > 0 {
+			l.FLogger.logFields(DEBUG, l.fields, format, a...)
+		} else {
+			l.FLogger.Debug(format, a...)
+		}
 	}
 	if l.CLogger != nil && !l.isClose {
-		l.CLogger.Debug(format, a...)
+		if len(l.fields) > 0 {
+			l.CLogger.logFields(DEBUG, l.fields, format, a...)
+		} else {
+			l.CLogger.Debug(format, a...)
+		}
+	}
+	if l.KLogger != nil && !l.isClose {
+		if len(l.fields) > 0 {
+			l.KLogger.logFields(DEBUG, l.fields, format, a...)
+		} else {
+			l.KLogger.Debug(format, a...)
+		}
+	}
+	if !l.isClose {
+		l.fireHooks(DEBUG, format, a...)
 	}
-
 }
 func (l *Log) Info(format string, a ...interface{}) {
+	if l.sampler != nil && !l.sampler.Allow(INFO, format) {
+		return
+	}
 	if l.FLogger != nil && !l.isClose {
-		l.FLogger.Info(format, a...)
+		if len(l.fields) > 0 {
+			l.FLogger.logFields(INFO, l.fields, format, a...)
+		} else {
+			l.FLogger.Info(format, a...)
+		}
 	}
 	if l.CLogger != nil && !l.isClose {
-		l.CLogger.Info(format, a...)
+		if len(l.fields) > 0 {
+			l.CLogger.logFields(INFO, l.fields, format, a...)
+		} else {
+			l.CLogger.Info(format, a...)
+		}
+	}
+	if l.KLogger != nil && !l.isClose {
+		if len(l.fields) > 0 {
+			l.KLogger.logFields(INFO, l.fields, format, a...)
+		} else {
+			l.KLogger.Info(format, a...)
+		}
+	}
+	if !l.isClose {
+		l.fireHooks(INFO, format, a...)
 	}
 }
 func (l *Log) Warn(format string, a ...interface{}) {
+	if l.sampler != nil && !l.sampler.Allow(WARN, format) {
+		return
+	}
 	if l.FLogger != nil && !l.isClose {
-		l.FLogger.Warn(format, a...)
+		if len(l.fields) > 0 {
+			l.FLogger.logFields(WARN, l.fields, format, a...)
+		} else {
+			l.FLogger.Warn(format, a...)
+		}
 	}
 	if l.CLogger != nil && !l.isClose {
-		l.CLogger.Warn(format, a...)
+		if len(l.fields) > 0 {
+			l.CLogger.logFields(WARN, l.fields, format, a...)
+		} else {
+			l.CLogger.Warn(format, a...)
+		}
+	}
+	if l.KLogger != nil && !l.isClose {
+		if len(l.fields) > 0 {
+			l.KLogger.logFields(WARN, l.fields, format, a...)
+		} else {
+			l.KLogger.Warn(format, a...)
+		}
+	}
+	if !l.isClose {
+		l.fireHooks(WARN, format, a...)
 	}
 }
 func (l *Log) Error(format string, a ...interface{}) {
+	if l.sampler != nil && !l.sampler.Allow(ERROR, format) {
+		return
+	}
 	if l.FLogger != nil && !l.isClose {
-		l.FLogger.Error(format, a...)
+		if len(l.fields) > 0 {
+			l.FLogger.logFields(ERROR, l.fields, format, a...)
+		} else {
+			l.FLogger.Error(format, a...)
+		}
 	}
 	if l.CLogger != nil && !l.isClose {
-		l.CLogger.Error(format, a...)
+		if len(l.fields) > 0 {
+			l.CLogger.logFields(ERROR, l.fields, format, a...)
+		} else {
+			l.CLogger.Error(format, a...)
+		}
+	}
+	if l.KLogger != nil && !l.isClose {
+		if len(l.fields) > 0 {
+			l.KLogger.logFields(ERROR, l.fields, format, a...)
+		} else {
+			l.KLogger.Error(format, a...)
+		}
+	}
+	if !l.isClose {
+		l.fireHooks(ERROR, format, a...)
 	}
 }
 func (l *Log) Fatal(format string, a ...interface{}) {
 	if l.FLogger != nil && !l.isClose {
-		l.FLogger.Fatal(format, a...)
+		if len(l.fields) > 0 {
+			l.FLogger.logFields(FATAL, l.fields, format, a...)
+		} else {
+			l.FLogger.Fatal(format, a...)
+		}
 	}
 	if l.CLogger != nil && !l.isClose {
-		l.CLogger.Fatal(format, a...)
+		if len(l.fields) > 0 {
+			l.CLogger.logFields(FATAL, l.fields, format, a...)
+		} else {
+			l.CLogger.Fatal(format, a...)
+		}
+	}
+	if l.KLogger != nil && !l.isClose {
+		if len(l.fields) > 0 {
+			l.KLogger.logFields(FATAL, l.fields, format, a...)
+		} else {
+			l.KLogger.Fatal(format, a...)
+		}
+	}
+	if !l.isClose {
+		l.fireHooks(FATAL, format, a...)
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 type consoleLogger struct {
 	level      Level
 	timeFormat string
+	formatter  Formatter // 日志格式化器，默认TextFormatter
 }
 
 // newConsoleLogger 构造函数
@@ -16,6 +17,7 @@ func newConsoleLogger() *consoleLogger {
 	return &consoleLogger{
 		level:      defaultLevel,
 		timeFormat: defaultTimeFormat,
+		formatter:  &TextFormatter{},
 	}
 }
 
@@ -25,27 +27,40 @@ func (c *consoleLogger) enable(logeLevel Level) bool {
 }
 
 // log 格式化输出内容
-func (c *consoleLogger) log(lv Level, format interface{}, a ...interface{}) {
+func (c *consoleLogger) log(lv Level, fields map[string]interface{}, format interface{}, a ...interface{}) {
 	if c.enable(lv) {
 		msg := fmt.Sprintf(fmt.Sprintf("%v", format), a...)
 		now := time.Now()
 		fileName, funcName, lineNo := traceInfo(4)
-		fmt.Printf("[%s] [%s] [%s:%s:%d] %s\n", now.Format(c.timeFormat),
-			l2S(lv), fileName, funcName, lineNo, msg)
+		logTmp := &logMsg{
+			level:     lv,
+			msg:       msg,
+			funcName:  funcName,
+			fileName:  fileName,
+			line:      lineNo,
+			timeStamp: now.Format(c.timeFormat),
+			fields:    fields,
+		}
+		fmt.Print(string(c.formatter.Format(logTmp)))
 	}
 }
+
+// logFields 携带上下文字段的日志输出，供Log.WithFields链路调用
+func (c *consoleLogger) logFields(lv Level, fields map[string]interface{}, format interface{}, a ...interface{}) {
+	c.log(lv, fields, format, a...)
+}
 func (c *consoleLogger) Debug(format interface{}, a ...interface{}) {
-	c.log(DEBUG, format, a...)
+	c.log(DEBUG, nil, format, a...)
 }
 func (c *consoleLogger) Info(format interface{}, a ...interface{}) {
-	c.log(INFO, format, a...)
+	c.log(INFO, nil, format, a...)
 }
 func (c *consoleLogger) Warn(format interface{}, a ...interface{}) {
-	c.log(WARN, format, a...)
+	c.log(WARN, nil, format, a...)
 }
 func (c *consoleLogger) Error(format interface{}, a ...interface{}) {
-	c.log(ERROR, format, a...)
+	c.log(ERROR, nil, format, a...)
 }
 func (c *consoleLogger) Fatal(format interface{}, a ...interface{}) {
-	c.log(FATAL, format, a...)
+	c.log(FATAL, nil, format, a...)
 }
@@ -1,9 +1,14 @@
 package alog
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,25 +26,34 @@ type fileLogger struct {
 	timeFormat string        // 时间输出格式化
 	filePath   string        // 日志文件保存的路径
 	fileName   string        // 日志文件保存的文件名
-	logChan    chan *logMsg  // 日志通道
+	ring       *ringBuffer   // 无锁环形缓冲区，替代原来的日志通道
 	closeChan  chan struct{} // 关闭对象channel
 	fileObj    *os.File      // 输出文件句柄
 	// 分割文件才需要考虑的参数
 	splitMode   int16 // 分割文件的模式
 	maxFileSize int64 // 限制文件大小
-	// 按时间分割文件的参数
-	duration  time.Duration // 时间间隔
-	startTime time.Time     // 当前时间起点
+	// 按时间分割文件的参数，splitMode/duration/startTime/rotateTimer会被调用方goroutine
+	// (SetSplitMode/SetSplitDuration)和写文件的后台goroutine并发读写，需要rotateMu保护
+	rotateMu    sync.Mutex
+	duration    time.Duration // 时间间隔
+	startTime   time.Time     // 当前时间起点
+	rotateTimer *time.Timer   // 到达startTime+duration时触发切割，空闲时也能正常切割
+	formatter   Formatter     // 日志格式化器，默认TextFormatter
+	// 备份文件清理相关参数
+	compressRotated bool          // 切割后的备份文件是否压缩为.gz
+	maxBackups      int           // 最多保留的备份文件数量，0表示不限制
+	maxAge          time.Duration // 备份文件最长保留时间，0表示不限制
 }
 
 // logMsg 输出日志消息结构
 type logMsg struct {
-	level     Level  // 日志等级
-	msg       string // 日记消息体
-	funcName  string // 函数名
-	fileName  string // 文件名
-	timeStamp string // 时间
-	line      int    // 行号
+	level     Level                  // 日志等级
+	msg       string                 // 日记消息体
+	funcName  string                 // 函数名
+	fileName  string                 // 文件名
+	timeStamp string                 // 时间
+	line      int                    // 行号
+	fields    map[string]interface{} // With/WithFields携带的上下文字段
 }
 
 // newFileLogger 构造函数
@@ -47,16 +61,17 @@ func newFileLogger() *fileLogger {
 	now := time.Now()
 	st := now.Add(-time.Duration(now.UnixNano() % defaultDuration.Nanoseconds()))
 	fl := &fileLogger{
-		level:       defaultLevel,                           // 默认Debug模式
-		timeFormat:  defaultTimeFormat,                      // 默认时间输出格式
-		filePath:    defaultFilePath,                        // 默认输出文件路径为./log/
-		fileName:    defaultFileName,                        // 默认输出文件名为ALog.log
-		maxFileSize: defaultMaxFileSize,                     // 默认大小为8MB
-		logChan:     make(chan *logMsg, defaultMaxChanSize), // 默认通道为5w，可后续设置
-		closeChan:   make(chan struct{}),                    // 判断程序是否关闭
-		splitMode:   SplitNone,                              // 默认不分割文件大小
-		duration:    defaultDuration,                        // 默认按一天分割
-		startTime:   st,                                     // 当前时间起点
+		level:       defaultLevel,                                    // 默认Debug模式
+		timeFormat:  defaultTimeFormat,                               // 默认时间输出格式
+		filePath:    defaultFilePath,                                 // 默认输出文件路径为./log/
+		fileName:    defaultFileName,                                 // 默认输出文件名为ALog.log
+		maxFileSize: defaultMaxFileSize,                              // 默认大小为8MB
+		ring:        newRingBuffer(defaultMaxChanSize, OverflowDrop), // 默认容量5w(向上取整为65536)，写满则丢弃
+		closeChan:   make(chan struct{}),                             // 判断程序是否关闭
+		splitMode:   SplitNone,                                       // 默认不分割文件大小
+		duration:    defaultDuration,                                 // 默认按一天分割
+		startTime:   st,                                              // 当前时间起点
+		formatter:   &TextFormatter{},                                // 默认文本格式化器
 	}
 	fl.initFile() // 按照文件路径和文件名将文件打开
 	return fl
@@ -81,38 +96,66 @@ func (f *fileLogger) initFile() {
 
 // writeLogBackground 后台写日志文件
 func (f *fileLogger) writeLogBackground() {
+	// 定时器始终运行，只有在SplitBaseOnTime模式下触发时才真正切割，
+	// 这样即使长时间没有日志写入(空闲一整夜)也能按时间点正常轮转
+	f.rotateMu.Lock()
+	f.rotateTimer = time.NewTimer(f.nextRotateDurationLocked())
+	f.rotateMu.Unlock()
+	defer f.rotateTimer.Stop()
 	for {
-		// 判断是否要切割文件
-		// 按文件大小切割
-		if f.splitMode == SplitBaseOnSize && f.checkSize(f.fileObj) {
-			newFile, err := f.splitFile(f.fileObj, SplitBaseOnSize) // 日志文件
-			if err != nil {
-				panic(err)
+		// 把环形缓冲区里现有的日志先写完
+		for {
+			logTmp, ok := f.ring.pop()
+			if !ok {
+				break
 			}
-			f.fileObj = newFile
+			// 判断是否要切割文件
+			// 按文件大小切割
+			if f.splitModeValue() == SplitBaseOnSize && f.checkSize(f.fileObj) {
+				newFile, err := f.splitFile(f.fileObj, SplitBaseOnSize) // 日志文件
+				if err != nil {
+					panic(err)
+				}
+				f.fileObj = newFile
+			}
+			// 将日志写入文件
+			f.writeIntoFile(logTmp)
 		}
 
 		select {
 		case <-f.closeChan: // 在程序退出前输出完所有的日志内容到文件中
-			// 1秒钟内通道没有日志则关闭日志对象
+			// 1秒钟内环形缓冲区没有新日志则关闭日志对象
 			t := time.NewTimer(time.Second)
 			for {
 				select {
-				case logTmp := <-f.logChan:
-					// 将日志写入文件
-					f.writeIntoFile(logTmp)
+				case <-f.ring.wakeup:
+					for {
+						logTmp, ok := f.ring.pop()
+						if !ok {
+							break
+						}
+						f.writeIntoFile(logTmp)
+					}
 					// 重置定时器
 					t.Reset(time.Second)
 				case <-t.C:
 					return
 				}
 			}
-		case logTmp := <-f.logChan: //取日志输出到文件
-			// 将日志写入文件
-			f.writeIntoFile(logTmp)
-		default:
-			// 取不到日志先休息500毫秒
-			time.Sleep(time.Millisecond * 500)
+		case <-f.ring.wakeup: // 有新日志写入，回到循环顶部drain
+		case <-f.rotateTimer.C: // 到达切割时间点
+			if f.splitModeValue() == SplitBaseOnTime {
+				f.rotateByTime()
+			} else {
+				// 不按时间切割时也把起始时间推进到下一个切割点，否则定时器会以0间隔反复触发
+				f.rotateMu.Lock()
+				for !f.startTime.Add(f.duration).After(time.Now()) {
+					f.startTime = f.startTime.Add(f.duration)
+				}
+				next := f.nextRotateDurationLocked()
+				f.rotateMu.Unlock()
+				f.rotateTimer.Reset(next)
+			}
 		}
 	}
 }
@@ -128,39 +171,75 @@ func (f *fileLogger) checkSize(file *os.File) bool {
 	return fileInfo.Size() >= f.maxFileSize
 }
 
-// TODO 后续完善此方法
-// checkSize 时间判断文件是否需要切割
-func (f *fileLogger) checkTime(nowTime time.Time) bool {
-	return nowTime.Sub(f.startTime) >= f.duration
+// nextRotateDurationLocked 计算距离下一个按时间切割的时间点还需要等待多久，调用者需持有f.rotateMu
+func (f *fileLogger) nextRotateDurationLocked() time.Duration {
+	d := f.startTime.Add(f.duration).Sub(time.Now())
+	if d < 0 {
+		d = 0
+	}
+	return d
 }
 
-// splitFileByTime 根据时间切割的处理函数
-func (f *fileLogger) splitFileByTime(logTime time.Time) {
-	if f.splitMode == SplitBaseOnTime && f.checkTime(logTime) {
-		newFile, err := f.splitFile(f.fileObj, SplitBaseOnTime) // 日志文件
-		subTime := logTime.UnixNano() % f.duration.Nanoseconds()
-		if subTime/int64(time.Second) == 0 {
-			f.startTime = logTime
-		} else {
-			f.startTime = logTime.Add(time.Duration(subTime))
-		}
-		f.startTime = logTime
-		if err != nil {
-			panic(err)
-		}
-		f.fileObj = newFile
+// nextRotateDuration 计算距离下一个按时间切割的时间点还需要等待多久
+func (f *fileLogger) nextRotateDuration() time.Duration {
+	f.rotateMu.Lock()
+	defer f.rotateMu.Unlock()
+	return f.nextRotateDurationLocked()
+}
+
+// splitModeValue 线程安全地读取当前切割模式
+func (f *fileLogger) splitModeValue() int16 {
+	f.rotateMu.Lock()
+	defer f.rotateMu.Unlock()
+	return f.splitMode
+}
+
+// setSplitMode 线程安全地设置切割模式，并在后台goroutine的定时器已经启动时重置它，
+// 避免定时器一直按旧模式对应的时间点触发
+func (f *fileLogger) setSplitMode(mode int16) {
+	f.rotateMu.Lock()
+	f.splitMode = mode
+	timer := f.rotateTimer
+	next := f.nextRotateDurationLocked()
+	f.rotateMu.Unlock()
+	if timer != nil {
+		timer.Reset(next)
+	}
+}
+
+// setSplitDuration 线程安全地更新切割间隔及起始时间，并重置后台goroutine里已经启动的定时器，
+// 否则定时器会一直沿用goroutine启动时读到的旧duration/startTime触发
+func (f *fileLogger) setSplitDuration(duration time.Duration) {
+	now := time.Now()
+	f.rotateMu.Lock()
+	f.duration = duration
+	f.startTime = now.Add(-time.Duration(now.UnixNano() % duration.Nanoseconds()))
+	timer := f.rotateTimer
+	next := f.nextRotateDurationLocked()
+	f.rotateMu.Unlock()
+	if timer != nil {
+		timer.Reset(next)
 	}
 }
 
+// rotateByTime 按时间切割文件，并把起始时间推进到下一个切割点
+func (f *fileLogger) rotateByTime() {
+	newFile, err := f.splitFile(f.fileObj, SplitBaseOnTime) // 日志文件
+	if err != nil {
+		panic(err)
+	}
+	f.fileObj = newFile
+	f.rotateMu.Lock()
+	f.startTime = f.startTime.Add(f.duration)
+	next := f.nextRotateDurationLocked()
+	f.rotateMu.Unlock()
+	f.rotateTimer.Reset(next)
+}
+
 // writeIntoFile 将日志写入文件的处理函数
 func (f *fileLogger) writeIntoFile(logTmp *logMsg) {
-	// 取出日志，查看日志的时间
-	// 判断是否按文件时间切割
-	logTime, _ := time.ParseInLocation(f.timeFormat, logTmp.timeStamp, time.Local)
-	f.splitFileByTime(logTime)
-	// 把日志先拼出来
-	logInfo := fmt.Sprintf("[%s] [%s] [%s:%s:%d] %s\n", logTmp.timeStamp, l2S(logTmp.level), logTmp.funcName, logTmp.fileName, logTmp.line, logTmp.msg)
-	_, _ = fmt.Fprint(f.fileObj, logInfo)
+	// 交给格式化器拼装日志内容
+	_, _ = fmt.Fprint(f.fileObj, string(f.formatter.Format(logTmp)))
 }
 
 // splitFile 切割文件
@@ -170,16 +249,18 @@ func (f *fileLogger) splitFile(file *os.File, mode int16) (*os.File, error) {
 	if mode == SplitBaseOnSize {
 		nowStr = time.Now().Format("20060102150405")
 	} else if mode == SplitBaseOnTime {
-		if f.duration%(time.Hour*24) == 0 {
-			nowStr = f.startTime.Format("20060102")
-		} else if f.duration%time.Hour == 0 {
-			nowStr = f.startTime.Format("2006010215")
-		} else if f.duration%time.Minute == 0 {
-			nowStr = f.startTime.Format("200601021504")
+		f.rotateMu.Lock()
+		duration, startTime := f.duration, f.startTime
+		f.rotateMu.Unlock()
+		if duration%(time.Hour*24) == 0 {
+			nowStr = startTime.Format("20060102")
+		} else if duration%time.Hour == 0 {
+			nowStr = startTime.Format("2006010215")
+		} else if duration%time.Minute == 0 {
+			nowStr = startTime.Format("200601021504")
 		} else {
-			nowStr = f.startTime.Format("20060102150405")
+			nowStr = startTime.Format("20060102150405")
 		}
-		//nowStr = f.startTime.Format("20060102150405")
 	}
 	fileInfo, err := file.Stat()
 	if err != nil {
@@ -198,10 +279,89 @@ func (f *fileLogger) splitFile(file *os.File, mode int16) (*os.File, error) {
 		fmt.Printf("open new log file failed, err:%v\n", err)
 		return nil, err
 	}
-	// 4. 将打开的新日志文件对象赋值给 f.fileObj
+	// 4. 压缩/清理备份文件放到后台goroutine里做，不阻塞写日志
+	if f.compressRotated {
+		go f.compressBackup(newLogName)
+	} else {
+		go f.pruneBackups()
+	}
+	// 5. 将打开的新日志文件对象赋值给 f.fileObj
 	return fileObj, nil
 }
 
+// compressBackup 把切割后的备份文件压缩为.gz，压缩完成后再清理过期备份
+func (f *fileLogger) compressBackup(backupPath string) {
+	if err := gzipFile(backupPath); err != nil {
+		fmt.Printf("compress rotated log failed, err:%v\n", err)
+	}
+	f.pruneBackups()
+}
+
+// gzipFile 将srcPath压缩为srcPath+".gz"，压缩成功后删除原文件
+func gzipFile(srcPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(srcPath+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	gw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gw, src); err != nil {
+		_ = gw.Close()
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return os.Remove(srcPath)
+}
+
+// pruneBackups 根据SetMaxBackups/SetMaxAge策略删除多余或过期的备份文件
+func (f *fileLogger) pruneBackups() {
+	if f.maxBackups <= 0 && f.maxAge <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(f.filePath)
+	if err != nil {
+		fmt.Printf("read log dir failed, err:%v\n", err)
+		return
+	}
+	prefix := f.fileName + "."
+	var backups []os.DirEntry
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if strings.HasPrefix(name, prefix) && (strings.HasSuffix(name, ".bak") || strings.HasSuffix(name, ".bak.gz")) {
+			backups = append(backups, entry)
+		}
+	}
+	// 备份文件名里带有可排序的时间戳，字典序即为从旧到新
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Name() < backups[j].Name()
+	})
+	now := time.Now()
+	for i, entry := range backups {
+		fullPath := path.Join(f.filePath, entry.Name())
+		if f.maxAge > 0 {
+			if info, err := entry.Info(); err == nil && now.Sub(info.ModTime()) > f.maxAge {
+				_ = os.Remove(fullPath)
+				continue
+			}
+		}
+		if f.maxBackups > 0 && len(backups)-i > f.maxBackups {
+			_ = os.Remove(fullPath)
+		}
+	}
+}
+
 // Close 关闭日志对象
 func (f *fileLogger) Close() {
 	f.closeChan <- struct{}{} // 关闭log goroutine
@@ -214,12 +374,12 @@ func (f *fileLogger) enable(lv Level) bool {
 }
 
 // log 记录日志的方法
-func (f *fileLogger) log(lv Level, format string, a ...interface{}) {
+func (f *fileLogger) log(lv Level, fields map[string]interface{}, format string, a ...interface{}) {
 	if f.enable(lv) {
 		msg := fmt.Sprintf(format, a...)           // 拼装消息
 		now := time.Now()                          // 获取时间
 		fileName, funcName, lineNo := traceInfo(4) // 获取输出此信息的文件名函数名行号
-		// 先把日志发送到通道中
+		// 先把日志封装好，再写入环形缓冲区
 		logTmp := &logMsg{
 			level:     lv,
 			msg:       msg,
@@ -227,26 +387,39 @@ func (f *fileLogger) log(lv Level, format string, a ...interface{}) {
 			fileName:  fileName,
 			line:      lineNo,
 			timeStamp: now.Format(f.timeFormat),
+			fields:    fields,
 		}
-		select {
-		case f.logChan <- logTmp:
-		default:
-			// 把日志丢掉保证不出现阻塞
-		}
+		// 写入无锁环形缓冲区，写满时的行为由SetOverflowPolicy决定
+		f.ring.push(logTmp)
 	}
 }
+
+// DroppedCount 返回因环形缓冲区写满而被丢弃的日志数量
+func (f *fileLogger) DroppedCount() uint64 {
+	return f.ring.Dropped()
+}
+
+// WrittenCount 返回成功写入环形缓冲区的日志数量
+func (f *fileLogger) WrittenCount() uint64 {
+	return f.ring.Written()
+}
+
+// logFields 携带上下文字段的日志输出，供Log.WithFields链路调用
+func (f *fileLogger) logFields(lv Level, fields map[string]interface{}, format string, a ...interface{}) {
+	f.log(lv, fields, format, a...)
+}
 func (f *fileLogger) Debug(format string, a ...interface{}) {
-	f.log(DEBUG, format, a...)
+	f.log(DEBUG, nil, format, a...)
 }
 func (f *fileLogger) Info(format string, a ...interface{}) {
-	f.log(INFO, format, a...)
+	f.log(INFO, nil, format, a...)
 }
 func (f *fileLogger) Warn(format string, a ...interface{}) {
-	f.log(WARN, format, a...)
+	f.log(WARN, nil, format, a...)
 }
 func (f *fileLogger) Error(format string, a ...interface{}) {
-	f.log(ERROR, format, a...)
+	f.log(ERROR, nil, format, a...)
 }
 func (f *fileLogger) Fatal(format string, a ...interface{}) {
-	f.log(FATAL, format, a...)
+	f.log(FATAL, nil, format, a...)
 }
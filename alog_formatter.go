@@ -0,0 +1,55 @@
+package alog
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// 日志格式化相关代码
+
+// Formatter 日志格式化接口，负责将一条日志消息格式化为可输出的字节流
+type Formatter interface {
+	// Format 格式化日志消息，返回的字节流需自带结尾换行符
+	Format(msg *logMsg) []byte
+}
+
+// TextFormatter 默认的文本格式化器，输出 [时间] [等级] [文件:函数:行号] 内容 的人类可读格式
+type TextFormatter struct{}
+
+// Format 实现Formatter接口
+func (t *TextFormatter) Format(msg *logMsg) []byte {
+	return []byte(fmt.Sprintf("[%s] [%s] [%s:%s:%d] %s\n",
+		msg.timeStamp, l2S(msg.level), msg.fileName, msg.funcName, msg.line, msg.msg))
+}
+
+// jsonLogEntry JSONFormatter输出的JSON记录结构
+type jsonLogEntry struct {
+	Time   string                 `json:"ts"`
+	Level  string                 `json:"level"`
+	File   string                 `json:"file"`
+	Func   string                 `json:"func"`
+	Line   int                    `json:"line"`
+	Msg    string                 `json:"msg"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// JSONFormatter 结构化JSON格式化器，每条日志输出一行JSON，便于ELK/Loki等日志采集系统解析
+type JSONFormatter struct{}
+
+// Format 实现Formatter接口
+func (j *JSONFormatter) Format(msg *logMsg) []byte {
+	entry := jsonLogEntry{
+		Time:   msg.timeStamp,
+		Level:  l2S(msg.level),
+		File:   msg.fileName,
+		Func:   msg.funcName,
+		Line:   msg.line,
+		Msg:    msg.msg,
+		Fields: msg.fields,
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return []byte(fmt.Sprintf("marshal log entry failed, err:%v\n", err))
+	}
+	return append(b, '\n')
+}
@@ -0,0 +1,161 @@
+package alog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Hook/观察者相关代码，让Log从控制台+文件的两路输出变成可扩展的管道
+
+// 默认变量
+const defaultHookQueueSize = 1000
+
+// Entry 提供给Hook使用的日志条目
+type Entry struct {
+	Time   time.Time              // 记录时间
+	Level  Level                  // 日志等级
+	File   string                 // 文件名
+	Func   string                 // 函数名
+	Line   int                    // 行号
+	Msg    string                 // 日志消息体
+	Fields map[string]interface{} // With/WithFields携带的上下文字段
+}
+
+// MarshalJSON 让Level按字符串(如"ERROR")输出，方便下游消费
+func (e *Entry) MarshalJSON() ([]byte, error) {
+	type alias struct {
+		Time   time.Time              `json:"time"`
+		Level  string                 `json:"level"`
+		File   string                 `json:"file"`
+		Func   string                 `json:"func"`
+		Line   int                    `json:"line"`
+		Msg    string                 `json:"msg"`
+		Fields map[string]interface{} `json:"fields,omitempty"`
+	}
+	return json.Marshal(alias{
+		Time:   e.Time,
+		Level:  l2S(e.Level),
+		File:   e.File,
+		Func:   e.Func,
+		Line:   e.Line,
+		Msg:    e.Msg,
+		Fields: e.Fields,
+	})
+}
+
+// Hook 日志观察者接口，用于接入自定义的输出或告警渠道
+type Hook interface {
+	// Fire 处理一条日志条目
+	Fire(entry *Entry) error
+	// Levels 返回此Hook关心的日志等级，返回空切片表示对所有等级生效
+	Levels() []Level
+}
+
+// hookWorker 把一个Hook包装到独立的worker goroutine里运行，避免慢的Hook阻塞日志生产者。
+// mu保护closed标志和对entries的写入，确保fire不会在Close已经关闭通道之后再往里发送
+type hookWorker struct {
+	hook    Hook
+	entries chan *Entry
+	dropped uint64
+	mu      sync.Mutex
+	closed  bool
+}
+
+// newHookWorker 构造函数
+func newHookWorker(hook Hook) *hookWorker {
+	hw := &hookWorker{
+		hook:    hook,
+		entries: make(chan *Entry, defaultHookQueueSize),
+	}
+	go hw.run()
+	return hw
+}
+
+// run 串行消费entries通道，逐条调用Hook.Fire
+func (hw *hookWorker) run() {
+	for entry := range hw.entries {
+		if err := hw.hook.Fire(entry); err != nil {
+			fmt.Printf("fire hook failed, err:%v\n", err)
+		}
+	}
+}
+
+// fire 把日志条目投递给worker，队列满时直接丢弃并计数，保证不阻塞生产者。
+// 加锁后判断closed，避免和Close并发执行时向已关闭的entries通道发送而panic
+func (hw *hookWorker) fire(entry *Entry) {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+	if hw.closed {
+		atomic.AddUint64(&hw.dropped, 1)
+		return
+	}
+	select {
+	case hw.entries <- entry:
+	default:
+		atomic.AddUint64(&hw.dropped, 1)
+	}
+}
+
+// enabled 判断该Hook是否关心此等级
+func (hw *hookWorker) enabled(lv Level) bool {
+	levels := hw.hook.Levels()
+	if len(levels) == 0 {
+		return true
+	}
+	for _, l := range levels {
+		if l == lv {
+			return true
+		}
+	}
+	return false
+}
+
+// DroppedCount 返回因队列写满而被丢弃的日志条目数
+func (hw *hookWorker) DroppedCount() uint64 {
+	return atomic.LoadUint64(&hw.dropped)
+}
+
+// Close 关闭entries通道，待run()把剩余日志条目投递给Hook后退出worker goroutine。
+// 和fire共用同一把锁，保证通道不会在仍有生产者发送时被关闭
+func (hw *hookWorker) Close() {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+	if hw.closed {
+		return
+	}
+	hw.closed = true
+	close(hw.entries)
+}
+
+// AddHook 注册一个Hook，日志会在控制台/文件/Kafka输出之后异步派发给它
+func (l *Log) AddHook(hook Hook) *Log {
+	l.hooks = append(l.hooks, newHookWorker(hook))
+	return l
+}
+
+// fireHooks 把日志条目派发给所有注册的Hook
+func (l *Log) fireHooks(lv Level, format string, a ...interface{}) {
+	if len(l.hooks) == 0 {
+		return
+	}
+	msg := fmt.Sprintf(format, a...)
+	now := time.Now()
+	fileName, funcName, lineNo := traceInfo(3)
+	entry := &Entry{
+		Time:   now,
+		Level:  lv,
+		File:   fileName,
+		Func:   funcName,
+		Line:   lineNo,
+		Msg:    msg,
+		Fields: l.fields,
+	}
+	for _, hw := range l.hooks {
+		if hw.enabled(lv) {
+			hw.fire(entry)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package alog
+
+import "sync"
+
+// MemoryHook 在内存中保留最近的N条日志，适合配合/debug/logs一类的调试接口展示最近日志
+type MemoryHook struct {
+	mu      sync.Mutex
+	entries []Entry
+	size    int
+	next    int
+	full    bool
+	levels  []Level
+}
+
+// NewMemoryHook 构造函数，size为环形缓冲区保留的日志条数
+func NewMemoryHook(size int) *MemoryHook {
+	return &MemoryHook{
+		entries: make([]Entry, size),
+		size:    size,
+	}
+}
+
+// Levels 实现Hook接口
+func (m *MemoryHook) Levels() []Level {
+	return m.levels
+}
+
+// Fire 实现Hook接口，把日志条目写入环形缓冲区
+func (m *MemoryHook) Fire(entry *Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[m.next] = *entry
+	m.next = (m.next + 1) % m.size
+	if m.next == 0 {
+		m.full = true
+	}
+	return nil
+}
+
+// Snapshot 返回当前缓存的日志快照，按时间从旧到新排列
+func (m *MemoryHook) Snapshot() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.full {
+		out := make([]Entry, m.next)
+		copy(out, m.entries[:m.next])
+		return out
+	}
+	out := make([]Entry, m.size)
+	copy(out, m.entries[m.next:])
+	copy(out[m.size-m.next:], m.entries[:m.next])
+	return out
+}
@@ -0,0 +1,34 @@
+package alog
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// noopHook 测试用Hook，Fire什么都不做
+type noopHook struct{}
+
+func (noopHook) Fire(*Entry) error { return nil }
+func (noopHook) Levels() []Level   { return nil }
+
+// TestHookWorkerFireCloseInterleaving 并发调用fire和Close，保证不会出现
+// 向已关闭的entries通道发送而panic(对应hookWorker的并发关闭场景)
+func TestHookWorkerFireCloseInterleaving(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		hw := newHookWorker(noopHook{})
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				hw.fire(&Entry{Time: time.Now()})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			hw.Close()
+		}()
+		wg.Wait()
+	}
+}
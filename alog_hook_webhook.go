@@ -0,0 +1,50 @@
+package alog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 默认变量
+const defaultWebhookTimeout = time.Second * 5
+
+// WebhookHook 把日志条目以JSON形式POST到外部地址，适合接入Slack/飞书/钉钉等告警机器人
+type WebhookHook struct {
+	url    string
+	levels []Level
+	client *http.Client
+}
+
+// NewWebhookHook 构造函数，levels为空表示对所有等级都发送(通常建议只订阅ERROR/FATAL)
+func NewWebhookHook(url string, levels []Level) *WebhookHook {
+	return &WebhookHook{
+		url:    url,
+		levels: levels,
+		client: &http.Client{Timeout: defaultWebhookTimeout},
+	}
+}
+
+// Levels 实现Hook接口
+func (w *WebhookHook) Levels() []Level {
+	return w.levels
+}
+
+// Fire 实现Hook接口，将日志条目POST到webhook地址
+func (w *WebhookHook) Fire(entry *Entry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package alog
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 往Kafka里面写日志相关代码
+
+// 默认变量
+const (
+	defaultKafkaBatchSize     = 100
+	defaultKafkaFlushInterval = time.Second
+)
+
+// Producer 生产者接口，使用者可以注入 sarama、kgo 等具体的 Kafka 客户端实现，
+// 从而避免本库强制依赖某一个 Kafka SDK
+type Producer interface {
+	// Produce 将一条消息发布到指定的 topic，key 可以为 nil
+	Produce(topic string, key, value []byte) error
+	// Close 关闭生产者，释放底层连接
+	Close() error
+}
+
+// kafkaRecord 发布到Kafka的JSON记录结构
+type kafkaRecord struct {
+	Time   string                 `json:"ts"`
+	Level  string                 `json:"level"`
+	File   string                 `json:"file"`
+	Func   string                 `json:"func"`
+	Line   int                    `json:"line"`
+	Msg    string                 `json:"msg"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// kafkaLogger Kafka输出日志结构
+type kafkaLogger struct {
+	level         Level         // 日志等级
+	timeFormat    string        // 时间输出格式化
+	topic         string        // 发布的topic
+	batchSize     int           // 每批次发送的最大消息数
+	flushInterval time.Duration // 未凑满一批时的最长等待时间
+	producer      Producer      // 具体的生产者实现，由使用者注入
+	logChan       chan *logMsg  // 日志通道
+	closeChan     chan struct{} // 关闭对象channel
+	doneChan      chan struct{} // 后台goroutine发送完剩余日志退出后关闭
+}
+
+// newKafkaLogger 构造函数
+func newKafkaLogger() *kafkaLogger {
+	kl := &kafkaLogger{
+		level:         defaultLevel,                           // 默认Debug模式
+		timeFormat:    defaultTimeFormat,                      // 默认时间输出格式
+		batchSize:     defaultKafkaBatchSize,                  // 默认每批100条
+		flushInterval: defaultKafkaFlushInterval,              // 默认1秒钟凑不满一批也发送
+		logChan:       make(chan *logMsg, defaultMaxChanSize), // 默认通道为5w，可后续设置
+		closeChan:     make(chan struct{}),                    // 判断程序是否关闭
+		doneChan:      make(chan struct{}),                    // 后台goroutine退出信号
+	}
+	go kl.writeLogBackground() // 开启1个后台goroutine去批量发布日志到Kafka
+	return kl
+}
+
+// writeLogBackground 后台批量发布日志到Kafka
+func (k *kafkaLogger) writeLogBackground() {
+	batch := make([]*logMsg, 0, k.batchSize)
+	timer := time.NewTimer(k.flushInterval)
+	defer timer.Stop()
+	defer close(k.doneChan)
+	for {
+		select {
+		case <-k.closeChan: // 在程序退出前把剩余的日志内容都发送出去
+			// 1秒钟内通道没有日志则关闭日志对象
+			t := time.NewTimer(time.Second)
+			for {
+				select {
+				case logTmp := <-k.logChan:
+					batch = append(batch, logTmp)
+					if len(batch) >= k.batchSize {
+						k.flush(batch)
+						batch = batch[:0]
+					}
+					t.Reset(time.Second)
+				case <-t.C:
+					k.flush(batch)
+					return
+				}
+			}
+		case logTmp := <-k.logChan: // 取日志凑批次
+			batch = append(batch, logTmp)
+			if len(batch) >= k.batchSize {
+				k.flush(batch)
+				batch = batch[:0]
+				timer.Reset(k.flushInterval)
+			}
+		case <-timer.C: // 凑不满一批也要定期发送，避免日志积压
+			if len(batch) > 0 {
+				k.flush(batch)
+				batch = batch[:0]
+			}
+			timer.Reset(k.flushInterval)
+		}
+	}
+}
+
+// flush 把一批日志消息发布到Kafka
+func (k *kafkaLogger) flush(batch []*logMsg) {
+	if k.producer == nil || len(batch) == 0 {
+		return
+	}
+	for _, logTmp := range batch {
+		record := kafkaRecord{
+			Time:   logTmp.timeStamp,
+			Level:  l2S(logTmp.level),
+			File:   logTmp.fileName,
+			Func:   logTmp.funcName,
+			Line:   logTmp.line,
+			Msg:    logTmp.msg,
+			Fields: logTmp.fields,
+		}
+		value, err := json.Marshal(record)
+		if err != nil {
+			fmt.Printf("marshal kafka record failed, err:%v\n", err)
+			continue
+		}
+		if err := k.producer.Produce(k.topic, nil, value); err != nil {
+			fmt.Printf("produce kafka record failed, err:%v\n", err)
+		}
+	}
+}
+
+// Close 关闭日志对象
+func (k *kafkaLogger) Close() {
+	k.closeChan <- struct{}{} // 关闭log goroutine
+	<-k.doneChan              // 等待剩余日志发送完再关闭生产者
+	if k.producer != nil {
+		_ = k.producer.Close()
+	}
+}
+
+// enable 判断是否需要记录该日志
+func (k *kafkaLogger) enable(lv Level) bool {
+	return k.level <= lv
+}
+
+// log 记录日志的方法
+func (k *kafkaLogger) log(lv Level, fields map[string]interface{}, format string, a ...interface{}) {
+	if k.enable(lv) {
+		msg := fmt.Sprintf(format, a...)           // 拼装消息
+		now := time.Now()                          // 获取时间
+		fileName, funcName, lineNo := traceInfo(4) // 获取输出此信息的文件名函数名行号
+		// 先把日志发送到通道中
+		logTmp := &logMsg{
+			level:     lv,
+			msg:       msg,
+			funcName:  funcName,
+			fileName:  fileName,
+			line:      lineNo,
+			timeStamp: now.Format(k.timeFormat),
+			fields:    fields,
+		}
+		select {
+		case k.logChan <- logTmp:
+		default:
+			// 把日志丢掉保证不出现阻塞
+		}
+	}
+}
+
+// logFields 携带上下文字段的日志输出，供Log.WithFields链路调用
+func (k *kafkaLogger) logFields(lv Level, fields map[string]interface{}, format string, a ...interface{}) {
+	k.log(lv, fields, format, a...)
+}
+func (k *kafkaLogger) Debug(format string, a ...interface{}) {
+	k.log(DEBUG, nil, format, a...)
+}
+func (k *kafkaLogger) Info(format string, a ...interface{}) {
+	k.log(INFO, nil, format, a...)
+}
+func (k *kafkaLogger) Warn(format string, a ...interface{}) {
+	k.log(WARN, nil, format, a...)
+}
+func (k *kafkaLogger) Error(format string, a ...interface{}) {
+	k.log(ERROR, nil, format, a...)
+}
+func (k *kafkaLogger) Fatal(format string, a ...interface{}) {
+	k.log(FATAL, nil, format, a...)
+}
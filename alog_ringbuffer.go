@@ -0,0 +1,185 @@
+package alog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 无锁环形缓冲区(MPSC)相关代码，用于替换fileLogger原先"通道满了就直接丢弃"的写法
+// 算法参考经典的Vyukov有界队列，这里按单消费者场景做了适配
+
+// OverflowPolicy 环形缓冲区写满后的处理策略
+type OverflowPolicy int32
+
+const (
+	OverflowDrop       OverflowPolicy = iota // 丢弃本条新日志(默认行为，等价于原来select+default)
+	OverflowBlock                            // 阻塞生产者直到消费者腾出空间
+	OverflowDropOldest                       // 丢弃队列里最旧的一条，为新日志腾出空间
+)
+
+// ringCell 环形缓冲区里的一个槽位，seq用于标记该槽位当前处于可写还是可读状态
+type ringCell struct {
+	seq  uint64
+	data *logMsg
+}
+
+// ringBuffer 有界的多生产者单消费者无锁环形缓冲区
+type ringBuffer struct {
+	mask       uint64        // 容量-1，容量固定为2的幂
+	cells      []ringCell    // 槽位数组
+	enqueuePos uint64        // 下一个待写入的位置，由生产者CAS竞争
+	dequeuePos uint64        // 下一个待读取的位置
+	policy     int32         // 写满后的处理策略，OverflowPolicy的原子存储
+	wakeup     chan struct{} // 有新日志写入时通知消费者，避免消费者忙轮询
+	mu         sync.Mutex    // 仅配合notFull条件变量使用
+	notFull    *sync.Cond    // OverflowBlock策略下，生产者在队列满时阻塞等待
+	dropped    uint64        // 因队列已满而被丢弃的日志数
+	written    uint64        // 成功写入队列的日志数
+}
+
+// newRingBuffer 构造函数，capacity会被向上取整为2的幂
+func newRingBuffer(capacity int, policy OverflowPolicy) *ringBuffer {
+	size := 1
+	for size < capacity {
+		size <<= 1
+	}
+	rb := &ringBuffer{
+		mask:   uint64(size - 1),
+		cells:  make([]ringCell, size),
+		policy: int32(policy),
+		wakeup: make(chan struct{}, 1),
+	}
+	for i := range rb.cells {
+		rb.cells[i].seq = uint64(i)
+	}
+	rb.notFull = sync.NewCond(&rb.mu)
+	return rb
+}
+
+// setPolicy 运行时切换溢出策略，并唤醒可能因OverflowBlock而阻塞的生产者重新判断
+func (rb *ringBuffer) setPolicy(policy OverflowPolicy) {
+	atomic.StoreInt32(&rb.policy, int32(policy))
+	rb.mu.Lock()
+	rb.notFull.Broadcast()
+	rb.mu.Unlock()
+}
+
+// push 写入一条日志消息，队列写满时按policy处理，返回是否写入成功
+func (rb *ringBuffer) push(data *logMsg) bool {
+	for {
+		pos := atomic.LoadUint64(&rb.enqueuePos)
+		cell := &rb.cells[pos&rb.mask]
+		seq := atomic.LoadUint64(&cell.seq)
+		diff := int64(seq) - int64(pos)
+		switch {
+		case diff == 0:
+			// 槽位空闲，尝试抢占写入
+			if !atomic.CompareAndSwapUint64(&rb.enqueuePos, pos, pos+1) {
+				continue // 被其他生产者抢先，重新读取enqueuePos再试
+			}
+			cell.data = data
+			atomic.StoreUint64(&cell.seq, pos+1)
+			atomic.AddUint64(&rb.written, 1)
+			rb.notifyConsumer()
+			return true
+		case diff > 0:
+			continue // 另一个生产者正在写这个槽位，重新读取enqueuePos再试
+		default:
+			// 队列已满
+			switch OverflowPolicy(atomic.LoadInt32(&rb.policy)) {
+			case OverflowBlock:
+				// 满状态必须在rb.mu加锁后重新确认并在循环里Wait，否则消费者可能在
+				// 本goroutine加锁前就已经Signal，导致唤醒丢失，生产者永久阻塞
+				rb.mu.Lock()
+				for rb.isFullLocked() && OverflowPolicy(atomic.LoadInt32(&rb.policy)) == OverflowBlock {
+					rb.notFull.Wait()
+				}
+				rb.mu.Unlock()
+			case OverflowDropOldest:
+				if !rb.dropOldest() {
+					atomic.AddUint64(&rb.dropped, 1)
+					return false
+				}
+			default: // OverflowDrop
+				atomic.AddUint64(&rb.dropped, 1)
+				return false
+			}
+		}
+	}
+}
+
+// isFullLocked 判断当前enqueuePos对应的槽位是否已被占用(队列已满)，调用者需持有rb.mu
+func (rb *ringBuffer) isFullLocked() bool {
+	pos := atomic.LoadUint64(&rb.enqueuePos)
+	cell := &rb.cells[pos&rb.mask]
+	seq := atomic.LoadUint64(&cell.seq)
+	return int64(seq)-int64(pos) < 0
+}
+
+// pop 取出一条日志消息，队列为空时返回false
+func (rb *ringBuffer) pop() (*logMsg, bool) {
+	for {
+		pos := atomic.LoadUint64(&rb.dequeuePos)
+		cell := &rb.cells[pos&rb.mask]
+		seq := atomic.LoadUint64(&cell.seq)
+		diff := int64(seq) - int64(pos+1)
+		switch {
+		case diff == 0:
+			if !atomic.CompareAndSwapUint64(&rb.dequeuePos, pos, pos+1) {
+				continue
+			}
+			data := cell.data
+			atomic.StoreUint64(&cell.seq, pos+rb.mask+1)
+			rb.mu.Lock()
+			rb.notFull.Signal()
+			rb.mu.Unlock()
+			return data, true
+		case diff < 0:
+			return nil, false // 队列为空
+		default:
+			continue // 和dropOldest竞争到了同一个槽位，重试
+		}
+	}
+}
+
+// dropOldest 丢弃队列里最旧的一条，为新日志腾出空间(OverflowDropOldest策略下使用)
+func (rb *ringBuffer) dropOldest() bool {
+	for {
+		pos := atomic.LoadUint64(&rb.dequeuePos)
+		cell := &rb.cells[pos&rb.mask]
+		seq := atomic.LoadUint64(&cell.seq)
+		diff := int64(seq) - int64(pos+1)
+		if diff < 0 {
+			return false // 消费者恰好已经取空，没有旧数据可丢
+		}
+		if diff > 0 {
+			continue
+		}
+		if atomic.CompareAndSwapUint64(&rb.dequeuePos, pos, pos+1) {
+			atomic.StoreUint64(&cell.seq, pos+rb.mask+1)
+			atomic.AddUint64(&rb.dropped, 1)
+			rb.mu.Lock()
+			rb.notFull.Signal()
+			rb.mu.Unlock()
+			return true
+		}
+	}
+}
+
+// notifyConsumer 非阻塞地唤醒消费者，channel已有信号时什么都不做
+func (rb *ringBuffer) notifyConsumer() {
+	select {
+	case rb.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Dropped 返回因队列已满而被丢弃的日志数量
+func (rb *ringBuffer) Dropped() uint64 {
+	return atomic.LoadUint64(&rb.dropped)
+}
+
+// Written 返回成功写入队列的日志数量
+func (rb *ringBuffer) Written() uint64 {
+	return atomic.LoadUint64(&rb.written)
+}
@@ -0,0 +1,149 @@
+package alog
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRingBufferPushPopFIFO 验证单生产者单消费者场景下push/pop保持先进先出语义
+func TestRingBufferPushPopFIFO(t *testing.T) {
+	rb := newRingBuffer(8, OverflowDrop)
+	for i := 0; i < 8; i++ {
+		if !rb.push(&logMsg{msg: fmt.Sprintf("msg-%d", i)}) {
+			t.Fatalf("push %d failed unexpectedly", i)
+		}
+	}
+	for i := 0; i < 8; i++ {
+		got, ok := rb.pop()
+		if !ok {
+			t.Fatalf("pop %d: expected data, got none", i)
+		}
+		want := fmt.Sprintf("msg-%d", i)
+		if got.msg != want {
+			t.Fatalf("pop %d: got %q, want %q", i, got.msg, want)
+		}
+	}
+	if _, ok := rb.pop(); ok {
+		t.Fatal("expected empty ring buffer after draining")
+	}
+}
+
+// TestRingBufferOverflowDrop 验证OverflowDrop策略下队列写满时新日志被丢弃且计数递增
+func TestRingBufferOverflowDrop(t *testing.T) {
+	rb := newRingBuffer(4, OverflowDrop)
+	for i := 0; i < 4; i++ {
+		if !rb.push(&logMsg{}) {
+			t.Fatalf("push %d should have succeeded", i)
+		}
+	}
+	if rb.push(&logMsg{}) {
+		t.Fatal("push into a full OverflowDrop ring should fail")
+	}
+	if rb.Dropped() != 1 {
+		t.Fatalf("Dropped() = %d, want 1", rb.Dropped())
+	}
+}
+
+// TestRingBufferOverflowDropOldest 验证OverflowDropOldest策略会丢弃最旧的一条为新日志腾出空间
+func TestRingBufferOverflowDropOldest(t *testing.T) {
+	rb := newRingBuffer(4, OverflowDropOldest)
+	for i := 0; i < 4; i++ {
+		rb.push(&logMsg{msg: fmt.Sprintf("msg-%d", i)})
+	}
+	if !rb.push(&logMsg{msg: "msg-4"}) {
+		t.Fatal("push into a full OverflowDropOldest ring should still succeed")
+	}
+	got, ok := rb.pop()
+	if !ok {
+		t.Fatal("expected data after OverflowDropOldest push")
+	}
+	if got.msg != "msg-1" {
+		t.Fatalf("oldest surviving entry = %q, want msg-1 (msg-0 should have been dropped)", got.msg)
+	}
+}
+
+// TestRingBufferOverflowBlockWakesUp 验证OverflowBlock策略下，消费者腾出空间后
+// 被阻塞的生产者一定能被唤醒，防止lost wakeup导致永久阻塞
+func TestRingBufferOverflowBlockWakesUp(t *testing.T) {
+	rb := newRingBuffer(2, OverflowBlock)
+	rb.push(&logMsg{})
+	rb.push(&logMsg{}) // 队列已满
+
+	done := make(chan struct{})
+	go func() {
+		rb.push(&logMsg{}) // 应该阻塞直到下面pop腾出空间
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond) // 留出时间让push goroutine先进入OverflowBlock分支
+	if _, ok := rb.pop(); !ok {
+		t.Fatal("expected to pop an entry to make room")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("producer stayed blocked after consumer freed a slot (lost wakeup)")
+	}
+}
+
+// 压测无锁环形缓冲区在不同并发度下的吞吐量
+
+// BenchmarkRingBufferPush 单生产者场景下的push吞吐量(默认OverflowDrop策略)
+func BenchmarkRingBufferPush(b *testing.B) {
+	rb := newRingBuffer(1<<16, OverflowDrop)
+	msg := &logMsg{msg: "benchmark"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rb.push(msg)
+	}
+}
+
+// BenchmarkRingBufferPushParallel 多生产者并发push的吞吐量，消费者在后台持续drain，
+// 避免队列写满后退化成OverflowDrop策略的直接丢弃
+func BenchmarkRingBufferPushParallel(b *testing.B) {
+	rb := newRingBuffer(1<<16, OverflowDrop)
+	msg := &logMsg{msg: "benchmark"}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				for { // 把剩余数据排空，避免生产者永远拿不到空槽位
+					if _, ok := rb.pop(); !ok {
+						return
+					}
+				}
+			default:
+				rb.pop()
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rb.push(msg)
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	wg.Wait()
+}
+
+// BenchmarkRingBufferPushPop 单生产者单消费者交替push/pop的吞吐量
+func BenchmarkRingBufferPushPop(b *testing.B) {
+	rb := newRingBuffer(1<<16, OverflowDrop)
+	msg := &logMsg{msg: "benchmark"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rb.push(msg)
+		rb.pop()
+	}
+}
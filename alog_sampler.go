@@ -0,0 +1,70 @@
+package alog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 采样/限流相关代码，用于避免热点代码路径里的日志调用打爆输出端
+
+// Sampler 采样器接口，在日志真正派发给各输出器之前决定该条日志是否允许通过
+type Sampler interface {
+	// Allow 判断该条日志是否允许通过，key通常取自调用处的格式化字符串，
+	// 用于区分不同调用点而不是按日志内容去重
+	Allow(level Level, key string) bool
+}
+
+// SetSampler 设置采样器，每次Debug/Info/Warn/Error调用前都会先经过它的过滤，Fatal不经过采样
+func (l *Log) SetSampler(sampler Sampler) *Log {
+	l.sampler = sampler
+	return l
+}
+
+// RateSampler 基于令牌桶的限流采样器，只对构造时指定的等级生效，其余等级直接放行
+type RateSampler struct {
+	level     Level
+	perSecond float64
+	burst     float64
+	mu        sync.Mutex
+	tokens    float64
+	lastTime  time.Time
+	dropped   uint64
+}
+
+// NewRateSampler 构造函数，perSecond为每秒补充的令牌数，burst为令牌桶容量
+func NewRateSampler(level Level, perSecond float64, burst int) *RateSampler {
+	return &RateSampler{
+		level:     level,
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		lastTime:  time.Now(),
+	}
+}
+
+// Allow 实现Sampler接口
+func (r *RateSampler) Allow(lv Level, _ string) bool {
+	if lv != r.level {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.tokens += now.Sub(r.lastTime).Seconds() * r.perSecond
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.lastTime = now
+	if r.tokens < 1 {
+		atomic.AddUint64(&r.dropped, 1)
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// DroppedCount 返回因限流而被丢弃的日志数量
+func (r *RateSampler) DroppedCount() uint64 {
+	return atomic.LoadUint64(&r.dropped)
+}
@@ -0,0 +1,65 @@
+package alog
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BurstSampler 按调用点限流的"先放过N条，之后每M条放一条"采样器，
+// 用来防止 for { log.Debug(...) } 这样的热点循环把输出端打垮
+type BurstSampler struct {
+	n       int64
+	m       int64
+	period  time.Duration
+	mu      sync.Mutex
+	hits    map[uint64]*burstHit
+	dropped uint64
+}
+
+// burstHit 记录单个调用点(按格式化字符串哈希区分)在当前窗口内的计数
+type burstHit struct {
+	count      int64
+	windowEnds time.Time
+}
+
+// NewBurstSampler 构造函数，period为重新开始计数的窗口长度
+func NewBurstSampler(n, m int, period time.Duration) *BurstSampler {
+	return &BurstSampler{
+		n:      int64(n),
+		m:      int64(m),
+		period: period,
+		hits:   make(map[uint64]*burstHit),
+	}
+}
+
+// Allow 实现Sampler接口
+func (b *BurstSampler) Allow(_ Level, key string) bool {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	h := hash.Sum64()
+
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hit, ok := b.hits[h]
+	if !ok || now.After(hit.windowEnds) {
+		hit = &burstHit{windowEnds: now.Add(b.period)}
+		b.hits[h] = hit
+	}
+	hit.count++
+	if hit.count <= b.n {
+		return true
+	}
+	if b.m > 0 && (hit.count-b.n)%b.m == 0 {
+		return true
+	}
+	atomic.AddUint64(&b.dropped, 1)
+	return false
+}
+
+// DroppedCount 返回因采样而被丢弃的日志数量
+func (b *BurstSampler) DroppedCount() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}